@@ -0,0 +1,45 @@
+package swallowjson
+
+import (
+	"strings"
+	"testing"
+)
+
+const rawFolded = `{ "Foo": "alpha", "BAR": 42, "baz": "extra" }`
+
+func TestUnmarshalWithOptionsCaseInsensitive(t *testing.T) {
+	var f foo1
+	if err := UnmarshalWithOptions(&f, "Rest", []byte(rawFolded), WithCaseInsensitiveKeys()); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Foo != "alpha" || f.Bar != 42 {
+		t.Errorf("case-folded fields not matched: %+v", f)
+	}
+	if v, ok := f.Rest["baz"]; !ok || v != "extra" {
+		t.Errorf("unmatched key should have gone to spillover under its original case: %+v", f.Rest)
+	}
+}
+
+func TestUnmarshalWithExactByDefault(t *testing.T) {
+	var f foo1
+	if err := UnmarshalWith(&f, "Rest", []byte(rawFolded)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Foo != "" || f.Bar != 0 {
+		t.Errorf("expected no exact match without WithCaseInsensitiveKeys, got %+v", f)
+	}
+	if len(f.Rest) != 3 {
+		t.Errorf("expected all three keys to spill without case folding, got %+v", f.Rest)
+	}
+}
+
+func TestUnmarshalWithReaderCaseInsensitive(t *testing.T) {
+	var f foo1
+	err := UnmarshalWithReader(&f, "Rest", strings.NewReader(rawFolded), WithCaseInsensitiveKeys())
+	if err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Foo != "alpha" || f.Bar != 42 {
+		t.Errorf("case-folded fields not matched: %+v", f)
+	}
+}
@@ -20,6 +20,28 @@ map keyed by string.  The type of map values is handled reliably, returning a
 JSON error if unsuitable.
 Common types to use might be `interface{}` or `json.RawMessage`.
 
+MarshalWith is the encoding counterpart, for implementing MarshalJSON so that
+the swallowed keys are re-emitted alongside the declared fields, allowing a
+decode-modify-encode round-trip without losing unknown data.
+
+UnmarshalWithReader streams the decode directly off an io.Reader, for
+callers decoding large objects where only the unknown fields are expected
+to be big; see its documentation for the available Options.
+
+Known fields whose type is itself a struct (or a pointer, slice, or
+map-by-string of one) carrying a tag-declared spillover field are decoded
+recursively, so nesting swallowjson types no longer requires every
+intermediate struct to hand-roll an UnmarshalJSON method.
+
+UnmarshalWithOptions takes the same arguments as UnmarshalWith plus a set of
+Options, including WithCaseInsensitiveKeys to reproduce encoding/json's
+case-folding fallback for matching incoming keys against declared fields.
+
+RegisterSpillType (and the per-call WithSpillFactory option) let individual
+spillover keys decode into a concrete registered Go type instead of the
+spillover map's declared value type, for open-ended, discriminated-union
+style schemas where each known "extension" key has its own shape.
+
 Errors are either of type swallowjson.SwallowError or are bubbled through from
 the json or reflect packages.
 */
@@ -53,6 +75,7 @@ func (se SwallowError) Error() string {
 var (
 	ErrGivenNonStringKey       = SwallowError{s: "given object with non-string key"}
 	ErrMalformedJSON           = SwallowError{s: "given malformed JSON"}
+	ErrMaxDepthExceeded        = SwallowError{s: "nested object/array exceeded configured max depth"} // only under WithMaxDepth, so not from UnmarshalWith itself
 	ErrMissingSpilloverField   = SwallowError{s: "target struct missing specified spillover field"}
 	ErrNotGivenMutable         = SwallowError{s: "not given something which we can assign to"}
 	ErrNotGivenStruct          = SwallowError{s: "not given a struct in the raw stream"}
@@ -94,29 +117,73 @@ func UnmarshalWith(target interface{}, spilloverName string, raw []byte) error {
 	if me.Kind() != reflect.Struct {
 		return ErrNotStructHolder
 	}
-	spillInto := me.FieldByName(spilloverName)
-	if spillInto.Kind() == 0 {
-		return ErrMissingSpilloverField
+	return unmarshalStructWith(me, spilloverName, raw)
+}
+
+// UnmarshalWithOptions is UnmarshalWith with the addition of
+// behavior-modifying Options, such as WithCaseInsensitiveKeys.
+func UnmarshalWithOptions(target interface{}, spilloverName string, raw []byte, opts ...Option) error {
+	me := reflect.ValueOf(target)
+	if me.Kind() != reflect.Ptr {
+		return ErrNotGivenMutable
 	}
-	if spillInto.Kind() != reflect.Map {
-		return ErrSpillNotRightMap
+	me = me.Elem()
+	if me.Kind() != reflect.Struct {
+		return ErrNotStructHolder
 	}
-	if spillInto.Type().Key().Kind() != reflect.String {
-		return ErrSpillNotRightMap
+	return unmarshalStructWith(me, spilloverName, raw, opts...)
+}
+
+// unmarshalStructWith merges opts and hands off to unmarshalStructWithOptions,
+// the core of UnmarshalWith.
+func unmarshalStructWith(me reflect.Value, spilloverName string, raw []byte, opts ...Option) error {
+	var uo unmarshalOptions
+	for _, opt := range opts {
+		opt(&uo)
 	}
-	// if the caller specifies a map value type other than interface{}, that's
-	// on them; things might work, or they might panic on mismatch.  Panic is
-	// the right failure mode, so we just try to Convert and let that panic.
-	spillValueType := spillInto.Type().Elem()
-	if !spillInto.CanSet() {
-		return ErrUnsetableSpilloverField
+	return unmarshalStructWithOptions(me, spilloverName, raw, uo)
+}
+
+// unmarshalStructWithOptions is the core of UnmarshalWith, operating on an
+// already-dereferenced addressable struct value with options already
+// merged, so that recursive descent into nested swallowjson-aware structs
+// (see decodeKnownField) can call back into it with the same uo, without
+// re-merging opts or re-establishing a target interface{}.
+func unmarshalStructWithOptions(me reflect.Value, spilloverName string, raw []byte, uo unmarshalOptions) error {
+	var (
+		spillInto      reflect.Value
+		spillValueType reflect.Type
+	)
+	if uo.spillHandler == nil {
+		spillInto = me.FieldByName(spilloverName)
+		if spillInto.Kind() == 0 {
+			return ErrMissingSpilloverField
+		}
+		if spillInto.Kind() != reflect.Map {
+			return ErrSpillNotRightMap
+		}
+		if spillInto.Type().Key().Kind() != reflect.String {
+			return ErrSpillNotRightMap
+		}
+		// if the caller specifies a map value type other than interface{},
+		// that's on them; things might work, or they might panic on
+		// mismatch.  Panic is the right failure mode, so we just try to
+		// Convert and let that panic.
+		spillValueType = spillInto.Type().Elem()
+		if !spillInto.CanSet() {
+			return ErrUnsetableSpilloverField
+		}
 	}
 
 	met := me.Type()
 	fieldsLookup := make(map[string]int, met.NumField()-1)
-	// encoding/json has various case-insensitive fallbacks
-	// skip that; we don't need to be compatible, this is a _new_ API
-	// file a feature request with use-case if want that too
+	// encoding/json has various case-insensitive fallbacks; by default we
+	// don't bother, since this is a _new_ API, but UnmarshalWithOptions
+	// callers can opt in with WithCaseInsensitiveKeys.
+	var foldLookup map[string]int
+	if uo.caseInsensitive {
+		foldLookup = make(map[string]int, met.NumField()-1)
+	}
 	var (
 		sf       reflect.StructField
 		tag      string
@@ -128,15 +195,25 @@ func UnmarshalWith(target interface{}, spilloverName string, raw []byte) error {
 		if tag = sf.Tag.Get("json"); tag != "" {
 			sections = strings.Split(tag, ",")
 			jsonName = sections[0]
-			if jsonName != "-" {
-				fieldsLookup[jsonName] = i
+			if jsonName == "-" {
+				continue
 			}
 		} else {
-			fieldsLookup[sf.Name] = i
+			jsonName = sf.Name
+		}
+		fieldsLookup[jsonName] = i
+		if foldLookup != nil {
+			lower := strings.ToLower(jsonName)
+			if _, taken := foldLookup[lower]; !taken {
+				foldLookup[lower] = i
+			}
 		}
 	}
 
 	dec := json.NewDecoder(bytes.NewReader(raw))
+	if uo.useNumber {
+		dec.UseNumber()
+	}
 	if err := swallowRuneToken(dec, '{', ErrNotGivenStruct); err != nil {
 		return err
 	}
@@ -152,29 +229,37 @@ func UnmarshalWith(target interface{}, spilloverName string, raw []byte) error {
 
 		// dec.Token() skips over colons!
 
-		var wantType reflect.Type
-		if fieldIndex, ok := fieldsLookup[key]; ok {
-			wantType = met.Field(fieldIndex).Type
-		} else {
-			wantType = spillValueType
+		fieldIndex, ok := fieldsLookup[key]
+		if !ok && foldLookup != nil {
+			fieldIndex, ok = foldLookup[strings.ToLower(key)]
+		}
+		if ok {
+			wantType := met.Field(fieldIndex).Type
+			vvl := reflect.MakeSlice(reflect.SliceOf(wantType), 1, 1)
+			vv := vvl.Index(0)
+			if err := decodeKnownField(dec, vv, wantType, uo); err != nil {
+				return err
+			}
+			me.Field(fieldIndex).Set(vv.Convert(wantType))
+			continue
 		}
 
-		vvl := reflect.MakeSlice(reflect.SliceOf(wantType), 1, 1)
-		vv := vvl.Index(0)
-		err = dec.Decode(vv.Addr().Interface())
+		if uo.spillHandler != nil {
+			if err := uo.spillHandler(key, dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		vv, err := decodeSpillEntry(dec, key, met, &uo, spillValueType)
 		if err != nil {
 			return err
 		}
-
-		if fieldIndex, ok := fieldsLookup[key]; ok {
-			me.Field(fieldIndex).Set(vv.Convert(met.Field(fieldIndex).Type))
-		} else {
-			kv := reflect.ValueOf(key)
-			if spillInto.IsNil() {
-				spillInto.Set(reflect.MakeMap(spillInto.Type()))
-			}
-			spillInto.SetMapIndex(kv, vv.Convert(spillValueType))
+		kv := reflect.ValueOf(key)
+		if spillInto.IsNil() {
+			spillInto.Set(reflect.MakeMap(spillInto.Type()))
 		}
+		spillInto.SetMapIndex(kv, vv.Convert(spillValueType))
 	}
 
 	return swallowRuneToken(dec, '}', ErrMalformedJSON)
@@ -0,0 +1,116 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// These errors may be returned by Unmarshal, and by the tag-driven
+// spillover discovery it's built on.
+var (
+	ErrMultipleSpilloverFields = SwallowError{s: "target struct has more than one field tagged as a spillover"}
+	ErrNoSpilloverField        = SwallowError{s: "target struct has no field tagged as a spillover"}
+)
+
+// Unmarshal discovers the spillover field automatically, instead of
+// requiring it be named explicitly as UnmarshalWith does.  A field is
+// marked as the spillover by tagging it either `json:"-,swallow"` or
+// `swallowjson:"spill"`.  If exactly one field is so tagged, decoding
+// proceeds as UnmarshalWith(target, <that field>, raw).  If no field is
+// tagged, Unmarshal falls back to plain encoding/json.Unmarshal semantics,
+// so it's safe to call on types which haven't opted in to swallowing.  If
+// more than one field is tagged, ErrMultipleSpilloverFields is returned.
+//
+// This removes the stringly-typed field name from UnmarshalJSON methods,
+// so renaming the spillover field stays a safe, mechanical refactor.
+//
+// Caution: unlike a bare `json:"-"`, the tag `json:"-,swallow"` is NOT
+// recognized as "ignore this field" by plain encoding/json (only a bare
+// "-" with no options is special-cased there); encoding/json instead reads
+// it as giving the field the literal JSON name "-".  That's harmless when
+// the type's own MarshalJSON goes through MarshalWith, which understands
+// the tag, but marshaling such a type with plain encoding/json (or any
+// other reflection-based tool that doesn't) will emit a spurious top-level
+// "-" member holding the whole spillover map.  Prefer `swallowjson:"spill"`
+// for the spillover field unless you specifically need it to also read as
+// ignored to other `json` consumers of the same tag.
+func Unmarshal(target interface{}, raw []byte) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return json.Unmarshal(raw, target)
+	}
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Struct {
+		return json.Unmarshal(raw, target)
+	}
+
+	name, err := discoverSpilloverField(elem.Type())
+	switch err {
+	case nil:
+		return UnmarshalWith(target, name, raw)
+	case ErrNoSpilloverField:
+		return json.Unmarshal(raw, target)
+	default:
+		return err
+	}
+}
+
+// discoverSpilloverField scans met's fields for the swallow tag, including
+// fields promoted from an anonymous (embedded) struct field, and returns the
+// name of the one field carrying it.  Since reflect.Value.FieldByName
+// already resolves promoted field names on its own, the name returned here
+// for an embedded field is just that field's own name, not a dotted path.
+// It returns ErrNoSpilloverField if no field is tagged, or
+// ErrMultipleSpilloverFields if more than one is, whether at the top level,
+// nested inside an embedded field, or split across both.
+func discoverSpilloverField(met reflect.Type) (string, error) {
+	found := ""
+	if err := scanForSwallowTag(met, &found); err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", ErrNoSpilloverField
+	}
+	return found, nil
+}
+
+// scanForSwallowTag walks met's fields looking for the swallow tag,
+// recursing into anonymous struct fields so a spillover field promoted from
+// an embedded type is found too.  Embedded pointer-to-struct fields are not
+// descended into, since a nil embedded pointer would make the promoted
+// field unreachable anyway.
+func scanForSwallowTag(met reflect.Type, found *string) error {
+	for i := 0; i < met.NumField(); i++ {
+		sf := met.Field(i)
+		if isSwallowTagged(sf) {
+			if *found != "" {
+				return ErrMultipleSpilloverFields
+			}
+			*found = sf.Name
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := scanForSwallowTag(sf.Type, found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isSwallowTagged reports whether sf is marked as a spillover field, either
+// via `json:"-,swallow"` or the dedicated `swallowjson:"spill"` tag.  See
+// Unmarshal's doc comment for why the former is not actually ignored by
+// plain encoding/json.
+func isSwallowTagged(sf reflect.StructField) bool {
+	if tag := sf.Tag.Get("json"); tag != "" {
+		sections := strings.Split(tag, ",")
+		for _, opt := range sections[1:] {
+			if opt == "swallow" {
+				return true
+			}
+		}
+	}
+	return sf.Tag.Get("swallowjson") == "spill"
+}
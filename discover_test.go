@@ -0,0 +1,114 @@
+package swallowjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type tagged1 struct {
+	Foo  string                 `json:"foo"`
+	Bar  int                    `json:"bar"`
+	Rest map[string]interface{} `json:"-,swallow"`
+}
+
+type tagged2 struct {
+	Foo  string                 `json:"foo"`
+	Bar  int                    `json:"bar"`
+	Rest map[string]interface{} `swallowjson:"spill"`
+}
+
+type untagged1 struct {
+	Foo string `json:"foo"`
+	Bar int    `json:"bar"`
+}
+
+type doubleTagged1 struct {
+	Foo  string                 `json:"foo"`
+	Rest map[string]interface{} `json:"-,swallow"`
+	Xtra map[string]interface{} `swallowjson:"spill"`
+}
+
+func TestUnmarshalDiscovery(t *testing.T) {
+	var t1 tagged1
+	if err := Unmarshal(&t1, []byte(rawA)); err != nil {
+		t.Fatal("tagged1 decode failed", err)
+	}
+	if len(t1.Rest) != 3 {
+		t.Errorf("tagged1 expected three spillover entries, got %d", len(t1.Rest))
+	}
+
+	var t2 tagged2
+	if err := Unmarshal(&t2, []byte(rawA)); err != nil {
+		t.Fatal("tagged2 decode failed", err)
+	}
+	if len(t2.Rest) != 3 {
+		t.Errorf("tagged2 expected three spillover entries, got %d", len(t2.Rest))
+	}
+}
+
+func TestUnmarshalDiscoveryFallback(t *testing.T) {
+	var u untagged1
+	if err := Unmarshal(&u, []byte(rawB)); err != nil {
+		t.Fatal("untagged1 decode failed", err)
+	}
+	if u.Foo != "alpha" || u.Bar != 42 {
+		t.Errorf("untagged1 fields not decoded: %+v", u)
+	}
+}
+
+func TestUnmarshalDiscoveryMultiple(t *testing.T) {
+	var d doubleTagged1
+	err := Unmarshal(&d, []byte(rawB))
+	if err != ErrMultipleSpilloverFields {
+		t.Errorf("expected ErrMultipleSpilloverFields, got %v", err)
+	}
+}
+
+type embeddedSpillInner struct {
+	Rest map[string]interface{} `json:"-,swallow"`
+}
+
+type embedsSpillover struct {
+	embeddedSpillInner
+	Name string `json:"name"`
+}
+
+// TestUnmarshalDiscoveryEmbedded checks that a spillover field promoted
+// from an anonymous (embedded) struct field is discovered the same as one
+// declared directly, instead of Unmarshal silently falling back to plain
+// encoding/json.Unmarshal and dropping every unknown key.
+func TestUnmarshalDiscoveryEmbedded(t *testing.T) {
+	var e embedsSpillover
+	raw := `{"name": "alpha", "extra": "wibble"}`
+	if err := Unmarshal(&e, []byte(raw)); err != nil {
+		t.Fatal("embedsSpillover decode failed", err)
+	}
+	if e.Name != "alpha" {
+		t.Errorf("top-level field not decoded: %+v", e)
+	}
+	if v, ok := e.Rest["extra"]; !ok || v != "wibble" {
+		t.Errorf("promoted spillover field did not capture unknown key: %+v", e.Rest)
+	}
+}
+
+// TestSwallowTagNotIgnoredByPlainJSON documents that `json:"-,swallow"`,
+// unlike a bare `json:"-"`, is not treated as "ignore this field" by plain
+// encoding/json: marshaling a tagged1 value with plain json.Marshal (no
+// MarshalWith involved) emits the field under the literal name "-" instead
+// of omitting it.  See Unmarshal's doc comment for the caveat this locks
+// in; don't "fix" this test without first fixing that caveat everywhere.
+func TestSwallowTagNotIgnoredByPlainJSON(t *testing.T) {
+	t1 := tagged1{Foo: "alpha", Bar: 42}
+	out, err := json.Marshal(&t1)
+	if err != nil {
+		t.Fatal("marshal failed", err)
+	}
+
+	var asMap map[string]json.RawMessage
+	if err := json.Unmarshal(out, &asMap); err != nil {
+		t.Fatal("re-decode as map failed", err)
+	}
+	if _, ok := asMap["-"]; !ok {
+		t.Errorf("expected spurious literal \"-\" member from plain encoding/json, got %s", out)
+	}
+}
@@ -0,0 +1,218 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// These errors may be returned by MarshalWith.
+var (
+	ErrSpillCollidesWithField = SwallowError{s: "spillover map key collides with a declared field's json name"}
+)
+
+// MarshalWith is the encoding counterpart to UnmarshalWith, used for
+// implementing MarshalJSON methods to satisfy the encoding/json.Marshaler
+// interface.  It walks source the same way UnmarshalWith walks a target,
+// honoring the json tag's name, "-", "omitempty" and ",string" options, and
+// emits each declared field as a JSON object member, in struct declaration
+// order.  Once the declared fields are emitted, each key held in the map
+// named by spilloverName is emitted in turn as a top-level object member,
+// marshalled with encoding/json.  If a spillover key collides with a
+// declared field's json name, ErrSpillCollidesWithField is returned instead
+// of silently emitting two members with the same name.
+func MarshalWith(source interface{}, spilloverName string) ([]byte, error) {
+	return marshalWith(source, spilloverName)
+}
+
+// MarshalWithInto is MarshalWith but writes to an io.Writer instead of
+// returning a byte slice, for callers who already have a stream to write to,
+// such as from within their own MarshalJSON method.
+func MarshalWithInto(w io.Writer, source interface{}, spilloverName string) error {
+	b, err := marshalWith(source, spilloverName)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func marshalWith(source interface{}, spilloverName string) ([]byte, error) {
+	me := reflect.ValueOf(source)
+	for me.Kind() == reflect.Ptr {
+		me = me.Elem()
+	}
+	if me.Kind() != reflect.Struct {
+		return nil, ErrNotStructHolder
+	}
+
+	spillFrom := me.FieldByName(spilloverName)
+	if spillFrom.Kind() == 0 {
+		return nil, ErrMissingSpilloverField
+	}
+	if spillFrom.Kind() != reflect.Map {
+		return nil, ErrSpillNotRightMap
+	}
+	if spillFrom.Type().Key().Kind() != reflect.String {
+		return nil, ErrSpillNotRightMap
+	}
+
+	met := me.Type()
+	declaredNames := make(map[string]bool, met.NumField())
+	for i := 0; i < met.NumField(); i++ {
+		sf := met.Field(i)
+		if sf.Name == spilloverName {
+			continue
+		}
+		name, _, _, ignored := jsonTagInfo(sf)
+		if !ignored {
+			declaredNames[name] = true
+		}
+	}
+	for _, key := range spillFrom.MapKeys() {
+		if declaredNames[key.String()] {
+			return nil, ErrSpillCollidesWithField
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+
+	for i := 0; i < met.NumField(); i++ {
+		sf := met.Field(i)
+		if sf.Name == spilloverName {
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, omitempty, asString, ignored := jsonTagInfo(sf)
+		if ignored {
+			continue
+		}
+		fv := me.Field(i)
+		if omitempty && isEmptyValue(fv) {
+			continue
+		}
+
+		encoded, err := marshalField(fv, asString)
+		if err != nil {
+			return nil, err
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		nameJSON, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(nameJSON)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+
+	for _, key := range spillFrom.MapKeys() {
+		encoded, err := json.Marshal(spillFrom.MapIndex(key).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		nameJSON, err := json.Marshal(key.String())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(nameJSON)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalField marshals a single declared field's value, honoring the
+// ",string" tag option the way encoding/json does: wrapping the usual JSON
+// encoding of bool, integer, float and string kinds in a JSON string.
+//
+// When fv is addressable (the normal case, since MarshalWith is called on a
+// dereferenced pointer), it marshals fv.Addr().Interface() rather than
+// fv.Interface(), so that a field type implementing json.Marshaler only on
+// a pointer receiver still gets dispatched to that method instead of
+// silently falling back to default struct reflection.
+func marshalField(fv reflect.Value, asString bool) ([]byte, error) {
+	iface := fv.Interface()
+	if fv.CanAddr() {
+		iface = fv.Addr().Interface()
+	}
+	if !asString {
+		return json.Marshal(iface)
+	}
+	switch fv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		inner, err := json.Marshal(iface)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(string(inner))
+	default:
+		return json.Marshal(iface)
+	}
+}
+
+// jsonTagInfo parses a struct field's json tag the way encoding/json does,
+// falling back to the field's Go name when there is no tag, and reporting
+// whether the field should be ignored entirely (tag is exactly "-").
+func jsonTagInfo(sf reflect.StructField) (name string, omitempty, asString, ignored bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false, true
+	}
+	if tag == "" {
+		return sf.Name, false, false, false
+	}
+	sections := strings.Split(tag, ",")
+	name = sections[0]
+	if name == "" {
+		name = sf.Name
+	}
+	for _, opt := range sections[1:] {
+		switch opt {
+		case "omitempty":
+			omitempty = true
+		case "string":
+			asString = true
+		}
+	}
+	return name, omitempty, asString, false
+}
+
+// isEmptyValue reports whether v is the "empty" value for its type, in the
+// same sense encoding/json uses to decide whether to honor "omitempty".
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
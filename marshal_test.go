@@ -0,0 +1,138 @@
+package swallowjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type bar1 struct {
+	Foo  string                 `json:"foo"`
+	Bar  int                    `json:"bar,omitempty"`
+	Skip string                 `json:"-"`
+	Rest map[string]interface{} `json:"-"`
+}
+
+func (b *bar1) UnmarshalJSON(raw []byte) error { return UnmarshalWith(b, "Rest", raw) }
+func (b *bar1) MarshalJSON() ([]byte, error)   { return MarshalWith(b, "Rest") }
+
+func TestMarshalRoundTrip(t *testing.T) {
+	var b1 bar1
+	if err := json.Unmarshal([]byte(rawA), &b1); err != nil {
+		t.Fatal("bar1 decode failed", err)
+	}
+
+	out, err := json.Marshal(&b1)
+	if err != nil {
+		t.Fatal("bar1 encode failed", err)
+	}
+
+	var b2 bar1
+	if err := json.Unmarshal(out, &b2); err != nil {
+		t.Fatalf("re-decode of %s failed: %s", out, err)
+	}
+
+	if b1.Foo != b2.Foo || b1.Bar != b2.Bar {
+		t.Errorf("round-trip field mismatch: %+v vs %+v", b1, b2)
+	}
+	if len(b1.Rest) != len(b2.Rest) {
+		t.Errorf("round-trip Rest length mismatch: %d vs %d", len(b1.Rest), len(b2.Rest))
+	}
+	for k, v := range b1.Rest {
+		if b2.Rest[k] != v {
+			t.Errorf("round-trip Rest[%q] mismatch: %v vs %v", k, v, b2.Rest[k])
+		}
+	}
+}
+
+func TestMarshalWithOmitsIgnoredField(t *testing.T) {
+	b := bar1{Foo: "alpha", Skip: "should not appear"}
+	out, err := MarshalWith(&b, "Rest")
+	if err != nil {
+		t.Fatal("encode failed", err)
+	}
+	if got := string(out); got != `{"foo":"alpha"}` {
+		t.Errorf("unexpected encode: %s", got)
+	}
+}
+
+func TestMarshalWithSpillCollision(t *testing.T) {
+	b := bar1{Foo: "alpha", Rest: map[string]interface{}{"foo": "duplicate"}}
+	_, err := MarshalWith(&b, "Rest")
+	if err != ErrSpillCollidesWithField {
+		t.Errorf("expected ErrSpillCollidesWithField, got %v", err)
+	}
+}
+
+func TestMarshalWithIntoWriter(t *testing.T) {
+	var buf bytes.Buffer
+	b := bar1{Foo: "alpha"}
+	if err := MarshalWithInto(&buf, &b, "Rest"); err != nil {
+		t.Fatal("encode into writer failed", err)
+	}
+	if got := buf.String(); got != `{"foo":"alpha"}` {
+		t.Errorf("unexpected encode: %s", got)
+	}
+}
+
+type holdsPointerMarshaler struct {
+	One  bar1                   `json:"one"`
+	Rest map[string]interface{} `json:"-"`
+}
+
+func (o *holdsPointerMarshaler) UnmarshalJSON(raw []byte) error { return UnmarshalWith(o, "Rest", raw) }
+func (o *holdsPointerMarshaler) MarshalJSON() ([]byte, error)   { return MarshalWith(o, "Rest") }
+
+// TestMarshalNestedPointerReceiver exercises a field whose MarshalJSON is
+// only declared on a pointer receiver (bar1's, here nested inside
+// holdsPointerMarshaler), checking that its own swallowed keys survive a
+// full round trip rather than being silently dropped by default struct
+// reflection.
+func TestMarshalNestedPointerReceiver(t *testing.T) {
+	raw := `{"one":{"foo":"alpha","unknown1":"present"}}`
+
+	var o1 holdsPointerMarshaler
+	if err := json.Unmarshal([]byte(raw), &o1); err != nil {
+		t.Fatal("holdsPointerMarshaler decode failed", err)
+	}
+
+	out, err := json.Marshal(&o1)
+	if err != nil {
+		t.Fatal("holdsPointerMarshaler encode failed", err)
+	}
+
+	var o2 holdsPointerMarshaler
+	if err := json.Unmarshal(out, &o2); err != nil {
+		t.Fatalf("re-decode of %s failed: %s", out, err)
+	}
+	if o2.One.Rest["unknown1"] != "present" {
+		t.Errorf("nested spillover key lost on round trip: %s decoded to %+v", out, o2.One)
+	}
+}
+
+type embedsUnexported struct {
+	unexportedEmbed
+	Foo  string                 `json:"foo"`
+	Rest map[string]interface{} `json:"-"`
+}
+
+type unexportedEmbed struct {
+	Hidden string
+}
+
+func (e *embedsUnexported) UnmarshalJSON(raw []byte) error { return UnmarshalWith(e, "Rest", raw) }
+func (e *embedsUnexported) MarshalJSON() ([]byte, error)   { return MarshalWith(e, "Rest") }
+
+// TestMarshalSkipsUnexportedAnonymousField guards against a panic on
+// fv.Interface() for an unexported (lower-case-named) embedded struct
+// field, a valid and common Go pattern that isn't itself given a json tag.
+func TestMarshalSkipsUnexportedAnonymousField(t *testing.T) {
+	e := embedsUnexported{Foo: "alpha"}
+	out, err := MarshalWith(&e, "Rest")
+	if err != nil {
+		t.Fatal("encode failed", err)
+	}
+	if got := string(out); got != `{"foo":"alpha"}` {
+		t.Errorf("unexpected encode: %s", got)
+	}
+}
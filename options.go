@@ -0,0 +1,62 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import "encoding/json"
+
+// Option configures the behavior of UnmarshalWithOptions and
+// UnmarshalWithReader.
+type Option func(*unmarshalOptions)
+
+type unmarshalOptions struct {
+	maxDepth        int
+	useNumber       bool
+	caseInsensitive bool
+	spillHandler    func(key string, dec *json.Decoder) error
+	spillFactory    func(key string) interface{}
+}
+
+// WithMaxDepth bounds how many levels of nested object/array will be walked
+// into while decoding an unknown key's value, under UnmarshalWithOptions
+// and UnmarshalWithReader alike.  Exceeding it yields ErrMaxDepthExceeded
+// instead of continuing to consume the input.  Zero, the default, means
+// unbounded.  It has no effect on known fields, which are decoded directly
+// by encoding/json.
+func WithMaxDepth(n int) Option {
+	return func(o *unmarshalOptions) { o.maxDepth = n }
+}
+
+// WithUseNumber calls UseNumber on the underlying json.Decoder, so that
+// spillover values typed as interface{} decode JSON numbers as json.Number
+// rather than float64, the same trade-off encoding/json itself offers.
+func WithUseNumber() Option {
+	return func(o *unmarshalOptions) { o.useNumber = true }
+}
+
+// WithSpillHandler lets the caller consume an unknown key's value directly
+// off the decoder, rather than having UnmarshalWithOptions or
+// UnmarshalWithReader materialize it into the spillover map.  The handler
+// must consume exactly one JSON value (scalar, object or array) from dec
+// before returning.  When set, the target's spillover field is never
+// inspected or written to.
+func WithSpillHandler(h func(key string, dec *json.Decoder) error) Option {
+	return func(o *unmarshalOptions) { o.spillHandler = h }
+}
+
+// WithSpillFactory supplies a per-call alternative to the RegisterSpillType
+// registry: for each unknown key, factory is called first, and if it
+// returns a non-nil prototype, that type is allocated fresh and decoded
+// into instead of the spillover map's own value type.  A nil return falls
+// through to the registry, and then to the map's value type.  This suits
+// dynamic key-to-type mappings that don't warrant a global registration.
+func WithSpillFactory(factory func(key string) interface{}) Option {
+	return func(o *unmarshalOptions) { o.spillFactory = factory }
+}
+
+// WithCaseInsensitiveKeys reproduces encoding/json's case-folding fallback:
+// an incoming key is matched against a declared field's json name (or Go
+// field name, for untagged fields) exactly first, and only if nothing
+// matches exactly does a case-insensitive match get tried.  A key that
+// matches neither goes to the spillover map under its original, as-received
+// form. Without this option, matching is exact only.
+func WithCaseInsensitiveKeys() Option {
+	return func(o *unmarshalOptions) { o.caseInsensitive = true }
+}
@@ -0,0 +1,67 @@
+package swallowjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestUnmarshalWithOptionsUseNumber checks that WithUseNumber has the same
+// effect under UnmarshalWithOptions as it does under UnmarshalWithReader,
+// rather than being silently ignored outside the streaming entry point.
+func TestUnmarshalWithOptionsUseNumber(t *testing.T) {
+	var f foo1
+	raw := `{"foo": "alpha", "bar": 42, "big": 123456789012345}`
+
+	if err := UnmarshalWithOptions(&f, "Rest", []byte(raw), WithUseNumber()); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	n, ok := f.Rest["big"].(json.Number)
+	if !ok {
+		t.Fatalf("expected json.Number under WithUseNumber, got %T: %v", f.Rest["big"], f.Rest["big"])
+	}
+	if n.String() != "123456789012345" {
+		t.Errorf("unexpected number value: %s", n)
+	}
+}
+
+// TestUnmarshalWithOptionsSpillHandler checks that WithSpillHandler is
+// honored by UnmarshalWithOptions, not just UnmarshalWithReader.
+func TestUnmarshalWithOptionsSpillHandler(t *testing.T) {
+	var f foo1
+	seen := map[string]string{}
+
+	err := UnmarshalWithOptions(&f, "Rest", []byte(rawA), WithSpillHandler(func(key string, dec *json.Decoder) error {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		seen[key] = string(raw)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Rest != nil {
+		t.Errorf("spillover map should be untouched when WithSpillHandler is set, got %+v", f.Rest)
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected spill handler to see three keys, got %d: %+v", len(seen), seen)
+	}
+}
+
+// TestUnmarshalWithOptionsMaxDepth checks that WithMaxDepth bounds
+// UnmarshalWithOptions the same way it bounds UnmarshalWithReader.
+func TestUnmarshalWithOptionsMaxDepth(t *testing.T) {
+	var f foo1
+	const nested = `{"foo": "alpha", "bar": 42, "deep": {"a": {"b": 1}}}`
+
+	err := UnmarshalWithOptions(&f, "Rest", []byte(nested), WithMaxDepth(1))
+	if err != ErrMaxDepthExceeded {
+		t.Errorf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+
+	var f2 foo1
+	if err := UnmarshalWithOptions(&f2, "Rest", []byte(nested), WithMaxDepth(2)); err != nil {
+		t.Errorf("unexpected error at sufficient depth: %v", err)
+	}
+}
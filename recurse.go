@@ -0,0 +1,145 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// spilloverFieldFor reports the tag-declared spillover field name for
+// struct type t, caching the answer on t so the tag scan only happens once
+// per type.  It deliberately does not report a spillover for types which
+// already implement json.Unmarshaler themselves: those are left to decode
+// via their own UnmarshalJSON (which may well call UnmarshalWith), rather
+// than have this package second-guess them.
+func spilloverFieldFor(t reflect.Type) (name string, ok bool) {
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+	if cached, hit := spilloverCache.Load(t); hit {
+		info := cached.(cachedSpillInfo)
+		return info.name, info.ok
+	}
+
+	info := cachedSpillInfo{}
+	if !reflect.PtrTo(t).Implements(unmarshalerType) {
+		if found, err := discoverSpilloverField(t); err == nil {
+			info.name = found
+			info.ok = true
+		}
+	}
+	spilloverCache.Store(t, info)
+	return info.name, info.ok
+}
+
+type cachedSpillInfo struct {
+	name string
+	ok   bool
+}
+
+var spilloverCache sync.Map // reflect.Type -> cachedSpillInfo
+
+// decodeKnownField decodes the next JSON value off dec into out, which must
+// be addressable and of type ft, honoring uo the same way the caller's own
+// decode loop does.  It is the known-field counterpart of the plain
+// dec.Decode call used for spillover values: where ft (or, for
+// pointers/slices/maps, its element type) is a struct carrying a
+// tag-declared spillover field, the value is recursively swallow-decoded,
+// passing uo down so options such as WithCaseInsensitiveKeys and
+// WithUseNumber keep applying at every nesting level instead of only the
+// top one.
+//
+// Values that don't need recursion are decoded directly off dec, rather
+// than buffered through json.RawMessage and re-parsed, so that decoder-level
+// settings such as UseNumber (set on dec by the caller before this is
+// reached) aren't lost for known fields typed interface{} or similar.
+func decodeKnownField(dec *json.Decoder, out reflect.Value, ft reflect.Type, uo unmarshalOptions) error {
+	switch ft.Kind() {
+	case reflect.Struct:
+		if name, ok := spilloverFieldFor(ft); ok {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			return unmarshalStructWithOptions(out, name, raw, uo)
+		}
+
+	case reflect.Ptr:
+		if ft.Elem().Kind() == reflect.Struct {
+			if name, ok := spilloverFieldFor(ft.Elem()); ok {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return err
+				}
+				return decodeKnownElement(out, ft.Elem(), true, name, raw, uo)
+			}
+		}
+
+	case reflect.Slice:
+		elemType, elemIsPtr := elementStructType(ft.Elem())
+		if name, ok := spilloverFieldFor(elemType); ok {
+			var items []json.RawMessage
+			if err := dec.Decode(&items); err != nil {
+				return err
+			}
+			sl := reflect.MakeSlice(ft, len(items), len(items))
+			for i, item := range items {
+				if err := decodeKnownElement(sl.Index(i), elemType, elemIsPtr, name, item, uo); err != nil {
+					return err
+				}
+			}
+			out.Set(sl)
+			return nil
+		}
+
+	case reflect.Map:
+		if ft.Key().Kind() == reflect.String {
+			elemType, elemIsPtr := elementStructType(ft.Elem())
+			if name, ok := spilloverFieldFor(elemType); ok {
+				var items map[string]json.RawMessage
+				if err := dec.Decode(&items); err != nil {
+					return err
+				}
+				mp := reflect.MakeMapWithSize(ft, len(items))
+				for k, item := range items {
+					ev := reflect.New(ft.Elem()).Elem()
+					if err := decodeKnownElement(ev, elemType, elemIsPtr, name, item, uo); err != nil {
+						return err
+					}
+					mp.SetMapIndex(reflect.ValueOf(k), ev)
+				}
+				out.Set(mp)
+				return nil
+			}
+		}
+	}
+
+	return dec.Decode(out.Addr().Interface())
+}
+
+// elementStructType unwraps a single layer of pointer from a slice/map
+// element type, reporting whether it did, so []T and []*T (likewise
+// map[string]T and map[string]*T) can share the same spillover-recursion
+// logic in decodeKnownField.
+func elementStructType(elemType reflect.Type) (structType reflect.Type, isPtr bool) {
+	if elemType.Kind() == reflect.Ptr {
+		return elemType.Elem(), true
+	}
+	return elemType, false
+}
+
+// decodeKnownElement swallow-decodes a single slice/map element addressed
+// by slot, which is of type structType or, when elemIsPtr, *structType.  A
+// JSON null into a pointer element leaves slot nil, matching encoding/json.
+func decodeKnownElement(slot reflect.Value, structType reflect.Type, elemIsPtr bool, name string, raw json.RawMessage, uo unmarshalOptions) error {
+	if elemIsPtr {
+		if string(raw) == "null" {
+			return nil
+		}
+		slot.Set(reflect.New(structType))
+		return unmarshalStructWithOptions(slot.Elem(), name, raw, uo)
+	}
+	return unmarshalStructWithOptions(slot, name, raw, uo)
+}
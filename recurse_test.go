@@ -0,0 +1,122 @@
+package swallowjson
+
+import "testing"
+
+type inner1 struct {
+	A    string                 `json:"a"`
+	Rest map[string]interface{} `json:"-,swallow"`
+}
+
+type outer1 struct {
+	Name  string                 `json:"name"`
+	One   inner1                 `json:"one"`
+	Ptr   *inner1                `json:"ptr"`
+	List  []inner1               `json:"list"`
+	ByKey map[string]inner1      `json:"bykey"`
+	Rest  map[string]interface{} `json:"-"`
+}
+
+func (o *outer1) UnmarshalJSON(raw []byte) error { return UnmarshalWith(o, "Rest", raw) }
+
+const rawNested = `{
+	"name": "top",
+	"one":  { "a": "alpha", "unknown1": 1 },
+	"ptr":  { "a": "beta",  "unknown2": 2 },
+	"list": [ { "a": "gamma", "unknown3": 3 } ],
+	"bykey": { "x": { "a": "delta", "unknown4": 4 } },
+	"topUnknown": "wibble"
+}`
+
+func TestUnmarshalRecursiveStruct(t *testing.T) {
+	var o outer1
+	if err := UnmarshalWith(&o, "Rest", []byte(rawNested)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+
+	if o.Name != "top" {
+		t.Errorf("top-level field not decoded: %+v", o)
+	}
+	if len(o.Rest) != 1 || o.Rest["topUnknown"] != "wibble" {
+		t.Errorf("top-level spillover wrong: %+v", o.Rest)
+	}
+
+	if o.One.A != "alpha" || len(o.One.Rest) != 1 {
+		t.Errorf("nested struct field not swallowed: %+v", o.One)
+	}
+	if o.Ptr == nil || o.Ptr.A != "beta" || len(o.Ptr.Rest) != 1 {
+		t.Errorf("nested pointer field not swallowed: %+v", o.Ptr)
+	}
+	if len(o.List) != 1 || o.List[0].A != "gamma" || len(o.List[0].Rest) != 1 {
+		t.Errorf("nested slice field not swallowed: %+v", o.List)
+	}
+	if len(o.ByKey) != 1 || o.ByKey["x"].A != "delta" || len(o.ByKey["x"].Rest) != 1 {
+		t.Errorf("nested map field not swallowed: %+v", o.ByKey)
+	}
+}
+
+// TestUnmarshalRecursiveStructOptionsPropagate checks that an Option passed
+// to UnmarshalWithOptions keeps applying at every nesting level, not just
+// the outermost struct.
+func TestUnmarshalRecursiveStructOptionsPropagate(t *testing.T) {
+	var o outer1
+	raw := `{"ONE": {"A": "alpha"}}`
+	if err := UnmarshalWithOptions(&o, "Rest", []byte(raw), WithCaseInsensitiveKeys()); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if o.One.A != "alpha" {
+		t.Errorf("WithCaseInsensitiveKeys did not propagate into nested struct: %+v", o.One)
+	}
+}
+
+type outerPtrElems1 struct {
+	List  []*inner1              `json:"list"`
+	ByKey map[string]*inner1     `json:"bykey"`
+	Rest  map[string]interface{} `json:"-"`
+}
+
+func (o *outerPtrElems1) UnmarshalJSON(raw []byte) error { return UnmarshalWith(o, "Rest", raw) }
+
+// TestUnmarshalRecursiveSlicePointerElements checks that []*T and
+// map[string]*T, not just []T and map[string]T, recurse into their
+// elements' own spillover fields instead of silently decoding them whole
+// via encoding/json and losing each element's unknown keys.
+func TestUnmarshalRecursiveSlicePointerElements(t *testing.T) {
+	raw := `{
+		"list":  [ { "a": "gamma", "unknown3": 3 }, null ],
+		"bykey": { "x": { "a": "delta", "unknown4": 4 }, "y": null }
+	}`
+
+	var o outerPtrElems1
+	if err := UnmarshalWith(&o, "Rest", []byte(raw)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+
+	if len(o.List) != 2 || o.List[0] == nil || o.List[0].A != "gamma" || len(o.List[0].Rest) != 1 {
+		t.Errorf("nested []*T element not swallowed: %+v", o.List)
+	}
+	if o.List[1] != nil {
+		t.Errorf("JSON null slice element should decode to a nil pointer, got %+v", o.List[1])
+	}
+
+	if len(o.ByKey) != 2 || o.ByKey["x"] == nil || o.ByKey["x"].A != "delta" || len(o.ByKey["x"].Rest) != 1 {
+		t.Errorf("nested map[string]*T element not swallowed: %+v", o.ByKey)
+	}
+	if o.ByKey["y"] != nil {
+		t.Errorf("JSON null map element should decode to a nil pointer, got %+v", o.ByKey["y"])
+	}
+}
+
+// TestUnmarshalRecursivePointerFieldNull checks that a single *T known
+// field (not a slice/map element) also tolerates a JSON null, leaving it
+// nil instead of failing because unmarshalStructWithOptions was handed
+// "null" instead of an object.
+func TestUnmarshalRecursivePointerFieldNull(t *testing.T) {
+	var o outer1
+	raw := `{"ptr": null}`
+	if err := UnmarshalWith(&o, "Rest", []byte(raw)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if o.Ptr != nil {
+		t.Errorf("JSON null pointer field should decode to nil, got %+v", o.Ptr)
+	}
+}
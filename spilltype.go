@@ -0,0 +1,118 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+var (
+	spillTypeMu       sync.RWMutex
+	spillTypeRegistry = map[reflect.Type]map[string]reflect.Type{}
+)
+
+// ErrSpillTypeNotAssignable is returned when a type registered via
+// RegisterSpillType or produced by a WithSpillFactory factory isn't
+// assignable to the spillover field's own map value type; for example,
+// registering a struct pointer against a spillover field declared as
+// map[string]json.RawMessage.  Declare the spillover field as
+// map[string]interface{} (or a common interface the registered types all
+// implement) to use either feature.
+var ErrSpillTypeNotAssignable = SwallowError{s: "registered spill type is not assignable to the spillover field's value type"}
+
+// RegisterSpillType registers the concrete Go type of valueProto to be used
+// whenever structType decodes an unknown key matching key, instead of that
+// key landing in the spillover map typed as whatever the map's own value
+// type is.  This turns an otherwise uniform spillover map into a
+// discriminated union: each registered key gets its own concrete type,
+// decoded by encoding/json the normal way, while keys with no registration
+// still fall back to the spillover map's declared value type.
+//
+// valueProto is only consulted for its type; a zero value of the
+// appropriate sort (a pointer's element type, or the value type itself) is
+// allocated fresh for every decode.  Registration is global to the process
+// and is consulted by UnmarshalWith, UnmarshalWithOptions and
+// UnmarshalWithReader alike; it has no effect on MarshalWith, which simply
+// marshals whatever concrete value it finds in the spillover map.  The
+// registered type must be assignable to the spillover field's own map value
+// type (see ErrSpillTypeNotAssignable), so declare that field as
+// map[string]interface{} or a common interface unless every registered key
+// shares one concrete type.
+func RegisterSpillType(structType reflect.Type, key string, valueProto interface{}) {
+	spillTypeMu.Lock()
+	defer spillTypeMu.Unlock()
+	perType, ok := spillTypeRegistry[structType]
+	if !ok {
+		perType = make(map[string]reflect.Type)
+		spillTypeRegistry[structType] = perType
+	}
+	perType[key] = reflect.TypeOf(valueProto)
+}
+
+func lookupSpillType(structType reflect.Type, key string) (reflect.Type, bool) {
+	spillTypeMu.RLock()
+	defer spillTypeMu.RUnlock()
+	perType, ok := spillTypeRegistry[structType]
+	if !ok {
+		return nil, false
+	}
+	t, ok := perType[key]
+	return t, ok
+}
+
+// spillProtoTypeFor resolves the type to allocate for an unknown key,
+// consulting the per-call spill factory before the global registry.  It
+// returns nil when neither has an opinion, meaning the caller should fall
+// back to the spillover map's own value type.
+func spillProtoTypeFor(met reflect.Type, key string, uo *unmarshalOptions) reflect.Type {
+	if uo.spillFactory != nil {
+		if proto := uo.spillFactory(key); proto != nil {
+			return reflect.TypeOf(proto)
+		}
+	}
+	if t, ok := lookupSpillType(met, key); ok {
+		return t
+	}
+	return nil
+}
+
+// allocateForProto allocates a fresh, addressable value suitable for
+// decoding a JSON value of the given prototype's shape into.  target is
+// always a pointer, suitable for passing to (*json.Decoder).Decode or
+// json.Unmarshal; result is what should end up stored in the spillover map:
+// the pointer itself, if protoType is a pointer type, or the pointed-to
+// value otherwise, mirroring the shape of the registered/factory-produced
+// prototype.
+func allocateForProto(protoType reflect.Type) (target, result reflect.Value) {
+	if protoType.Kind() == reflect.Ptr {
+		target = reflect.New(protoType.Elem())
+		return target, target
+	}
+	target = reflect.New(protoType)
+	return target, target.Elem()
+}
+
+// decodeSpillEntry decodes the next JSON value off dec for an unknown key,
+// using a type registered via RegisterSpillType or WithSpillFactory for
+// (met, key) if one exists, and otherwise decoding into spillValueType as
+// UnmarshalWith always has.  uo.maxDepth, when set, still bounds the decode
+// the same way it does for any other spillover value.
+func decodeSpillEntry(dec *json.Decoder, key string, met reflect.Type, uo *unmarshalOptions, spillValueType reflect.Type) (reflect.Value, error) {
+	if protoType := spillProtoTypeFor(met, key, uo); protoType != nil {
+		target, result := allocateForProto(protoType)
+		if !result.Type().AssignableTo(spillValueType) {
+			return reflect.Value{}, ErrSpillTypeNotAssignable
+		}
+		if err := decodeBounded(dec, target.Interface(), uo.maxDepth, uo.useNumber); err != nil {
+			return reflect.Value{}, err
+		}
+		return result, nil
+	}
+
+	vvl := reflect.MakeSlice(reflect.SliceOf(spillValueType), 1, 1)
+	vv := vvl.Index(0)
+	if err := decodeBounded(dec, vv.Addr().Interface(), uo.maxDepth, uo.useNumber); err != nil {
+		return reflect.Value{}, err
+	}
+	return vv, nil
+}
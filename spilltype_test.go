@@ -0,0 +1,102 @@
+package swallowjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type extPayload struct {
+	Value string `json:"value"`
+}
+
+type registryHost struct {
+	Foo  string                 `json:"foo"`
+	Rest map[string]interface{} `json:"-"`
+}
+
+func (r *registryHost) UnmarshalJSON(raw []byte) error { return UnmarshalWith(r, "Rest", raw) }
+
+type rawMessageHost struct {
+	Foo  string                     `json:"foo"`
+	Rest map[string]json.RawMessage `json:"-"`
+}
+
+func (r *rawMessageHost) UnmarshalJSON(raw []byte) error { return UnmarshalWith(r, "Rest", raw) }
+
+func TestRegisterSpillType(t *testing.T) {
+	// registryHost exists only for this test, so the registration below,
+	// which has no unregister counterpart, can't bleed into other tests.
+	RegisterSpillType(reflect.TypeOf(registryHost{}), "ext", &extPayload{})
+
+	var h registryHost
+	raw := `{"foo": "alpha", "ext": {"value": "registered"}}`
+	if err := UnmarshalWith(&h, "Rest", []byte(raw)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+
+	got, ok := h.Rest["ext"].(*extPayload)
+	if !ok {
+		t.Fatalf("expected *extPayload in spillover, got %T: %+v", h.Rest["ext"], h.Rest["ext"])
+	}
+	if got.Value != "registered" {
+		t.Errorf("registered type decoded wrong value: %+v", got)
+	}
+}
+
+func TestRegisterSpillTypeNotAssignable(t *testing.T) {
+	RegisterSpillType(reflect.TypeOf(rawMessageHost{}), "ext", &extPayload{})
+
+	var h rawMessageHost
+	raw := `{"foo": "alpha", "ext": {"value": "registered"}}`
+	err := UnmarshalWith(&h, "Rest", []byte(raw))
+	if err != ErrSpillTypeNotAssignable {
+		t.Errorf("expected ErrSpillTypeNotAssignable, got %v", err)
+	}
+}
+
+func TestWithSpillFactory(t *testing.T) {
+	var f foo1
+	raw := `{"foo": "alpha", "bar": 42, "ext": {"value": "factory"}, "plain": "unchanged"}`
+
+	err := UnmarshalWithOptions(&f, "Rest", []byte(raw), WithSpillFactory(func(key string) interface{} {
+		if key == "ext" {
+			return &extPayload{}
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal("decode failed", err)
+	}
+
+	got, ok := f.Rest["ext"].(*extPayload)
+	if !ok {
+		t.Fatalf("expected *extPayload in spillover, got %T", f.Rest["ext"])
+	}
+	if got.Value != "factory" {
+		t.Errorf("factory-typed value decoded wrong: %+v", got)
+	}
+	if f.Rest["plain"] != "unchanged" {
+		t.Errorf("non-factory key should fall through to default type: %+v", f.Rest["plain"])
+	}
+}
+
+func TestUnmarshalWithReaderSpillFactory(t *testing.T) {
+	var f foo1
+	raw := `{"foo": "alpha", "bar": 42, "ext": {"value": "streamed"}}`
+
+	err := UnmarshalWithReader(&f, "Rest", strings.NewReader(raw), WithSpillFactory(func(key string) interface{} {
+		if key == "ext" {
+			return &extPayload{}
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal("decode failed", err)
+	}
+	got, ok := f.Rest["ext"].(*extPayload)
+	if !ok || got.Value != "streamed" {
+		t.Errorf("factory-typed value via reader decoded wrong: %+v", f.Rest["ext"])
+	}
+}
@@ -0,0 +1,207 @@
+package swallowjson // import "go.pennock.tech/swallowjson"
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalWithReader is UnmarshalWith but consumes tokens directly off a
+// json.Decoder wrapping r, rather than requiring the whole input to be
+// buffered into a byte slice first.  This suits decoding objects whose
+// known fields fit comfortably in memory but whose unknown fields might be
+// large; pair with WithSpillHandler to stream those values straight through
+// to their destination instead of materializing them.
+func UnmarshalWithReader(target interface{}, spilloverName string, r io.Reader, opts ...Option) error {
+	var so unmarshalOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+
+	me := reflect.ValueOf(target)
+	if me.Kind() != reflect.Ptr {
+		return ErrNotGivenMutable
+	}
+	me = me.Elem()
+	if me.Kind() != reflect.Struct {
+		return ErrNotStructHolder
+	}
+
+	var (
+		spillInto      reflect.Value
+		spillValueType reflect.Type
+	)
+	if so.spillHandler == nil {
+		spillInto = me.FieldByName(spilloverName)
+		if spillInto.Kind() == 0 {
+			return ErrMissingSpilloverField
+		}
+		if spillInto.Kind() != reflect.Map {
+			return ErrSpillNotRightMap
+		}
+		if spillInto.Type().Key().Kind() != reflect.String {
+			return ErrSpillNotRightMap
+		}
+		if !spillInto.CanSet() {
+			return ErrUnsetableSpilloverField
+		}
+		spillValueType = spillInto.Type().Elem()
+	}
+
+	met := me.Type()
+	fieldsLookup := make(map[string]int, met.NumField()-1)
+	var foldLookup map[string]int
+	if so.caseInsensitive {
+		foldLookup = make(map[string]int, met.NumField()-1)
+	}
+	for i := 0; i < met.NumField(); i++ {
+		sf := met.Field(i)
+		var name string
+		if tag := sf.Tag.Get("json"); tag != "" {
+			name = strings.Split(tag, ",")[0]
+			if name == "-" {
+				continue
+			}
+		} else {
+			name = sf.Name
+		}
+		fieldsLookup[name] = i
+		if foldLookup != nil {
+			lower := strings.ToLower(name)
+			if _, taken := foldLookup[lower]; !taken {
+				foldLookup[lower] = i
+			}
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	if so.useNumber {
+		dec.UseNumber()
+	}
+
+	if err := swallowRuneToken(dec, '{', ErrNotGivenStruct); err != nil {
+		return err
+	}
+	for dec.More() {
+		keyToken, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := keyToken.(string)
+		if !ok {
+			return ErrGivenNonStringKey
+		}
+
+		fieldIndex, ok := fieldsLookup[key]
+		if !ok && foldLookup != nil {
+			fieldIndex, ok = foldLookup[strings.ToLower(key)]
+		}
+		if ok {
+			wantType := met.Field(fieldIndex).Type
+			vvl := reflect.MakeSlice(reflect.SliceOf(wantType), 1, 1)
+			vv := vvl.Index(0)
+			if err := decodeKnownField(dec, vv, wantType, so); err != nil {
+				return err
+			}
+			me.Field(fieldIndex).Set(vv.Convert(wantType))
+			continue
+		}
+
+		if so.spillHandler != nil {
+			if err := so.spillHandler(key, dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		vv, err := decodeSpillEntry(dec, key, met, &so, spillValueType)
+		if err != nil {
+			return err
+		}
+		if spillInto.IsNil() {
+			spillInto.Set(reflect.MakeMap(spillInto.Type()))
+		}
+		spillInto.SetMapIndex(reflect.ValueOf(key), vv.Convert(spillValueType))
+	}
+
+	return swallowRuneToken(dec, '}', ErrMalformedJSON)
+}
+
+// decodeBounded decodes the next JSON value off dec into target, enforcing
+// maxDepth (when positive) against how many levels of nested object/array
+// the value contains.  maxDepth <= 0 means unbounded, and this is simply
+// dec.Decode.  Otherwise the captured value is decoded with a fresh
+// json.Decoder, which calls UseNumber when useNumber is set.
+func decodeBounded(dec *json.Decoder, target interface{}, maxDepth int, useNumber bool) error {
+	if maxDepth <= 0 {
+		return dec.Decode(target)
+	}
+	raw, err := captureBoundedValue(dec, 0, maxDepth)
+	if err != nil {
+		return err
+	}
+	sub := json.NewDecoder(bytes.NewReader(raw))
+	if useNumber {
+		sub.UseNumber()
+	}
+	return sub.Decode(target)
+}
+
+// captureBoundedValue walks the next JSON value off dec, re-serializing it
+// into raw JSON bytes, and fails with ErrMaxDepthExceeded if a nested
+// object or array would push past maxDepth.
+func captureBoundedValue(dec *json.Decoder, depth, maxDepth int) (json.RawMessage, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		return json.Marshal(tok)
+	}
+	if delim != '{' && delim != '[' {
+		return nil, SwallowError{s: "unexpected closing delimiter while capturing value"}
+	}
+	if depth+1 > maxDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(byte(delim))
+	first := true
+	for dec.More() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		if delim == '{' {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			keyJSON, err := json.Marshal(keyTok)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+		}
+		sub, err := captureBoundedValue(dec, depth+1, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(sub)
+	}
+	if _, err := dec.Token(); err != nil { // consume closing delimiter
+		return nil, err
+	}
+	if delim == '{' {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), nil
+}
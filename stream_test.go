@@ -0,0 +1,83 @@
+package swallowjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalWithReaderBasic(t *testing.T) {
+	var f foo1
+	if err := UnmarshalWithReader(&f, "Rest", strings.NewReader(rawA)); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Foo != "alpha" || f.Bar != 42 {
+		t.Errorf("known fields not decoded: %+v", f)
+	}
+	if len(f.Rest) != 3 {
+		t.Errorf("expected three spillover entries, got %d: %+v", len(f.Rest), f.Rest)
+	}
+}
+
+func TestUnmarshalWithReaderMaxDepth(t *testing.T) {
+	var f foo1
+	const nested = `{"foo": "alpha", "bar": 42, "deep": {"a": {"b": 1}}}`
+
+	err := UnmarshalWithReader(&f, "Rest", strings.NewReader(nested), WithMaxDepth(1))
+	if err != ErrMaxDepthExceeded {
+		t.Errorf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+
+	var f2 foo1
+	if err := UnmarshalWithReader(&f2, "Rest", strings.NewReader(nested), WithMaxDepth(2)); err != nil {
+		t.Errorf("unexpected error at sufficient depth: %v", err)
+	}
+}
+
+func TestUnmarshalWithReaderSpillHandler(t *testing.T) {
+	var f foo1
+	seen := map[string]string{}
+
+	err := UnmarshalWithReader(&f, "Rest", strings.NewReader(rawA), WithSpillHandler(func(key string, dec *json.Decoder) error {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		seen[key] = string(raw)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal("decode failed", err)
+	}
+	if f.Rest != nil {
+		t.Errorf("spillover map should be untouched when WithSpillHandler is set, got %+v", f.Rest)
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected spill handler to see three keys, got %d: %+v", len(seen), seen)
+	}
+}
+
+type hasAnyField struct {
+	Any  interface{}            `json:"any"`
+	Rest map[string]interface{} `json:"-"`
+}
+
+// TestUnmarshalWithReaderUseNumberKnownField guards against decodeKnownField
+// buffering a known interface{} field through a fresh json.Unmarshal call,
+// which would silently drop WithUseNumber's effect and lose precision on
+// large integers.
+func TestUnmarshalWithReaderUseNumberKnownField(t *testing.T) {
+	var h hasAnyField
+	raw := `{"any": 123456789012345}`
+
+	if err := UnmarshalWithReader(&h, "Rest", strings.NewReader(raw), WithUseNumber()); err != nil {
+		t.Fatal("decode failed", err)
+	}
+	n, ok := h.Any.(json.Number)
+	if !ok {
+		t.Fatalf("expected json.Number for known interface{} field under WithUseNumber, got %T: %v", h.Any, h.Any)
+	}
+	if n.String() != "123456789012345" {
+		t.Errorf("unexpected number value: %s", n)
+	}
+}